@@ -0,0 +1,419 @@
+package dsl
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+
+	"goa.design/goa/v3/eval"
+	"goa.design/structurizr/expr"
+)
+
+// fragments holds the Go DSL fragments registered via RegisterInclude, keyed
+// by the absolute path of the file that registered them. Include looks
+// fragments up here since a compiled Go file cannot be loaded from disk the
+// way a JSON workspace can.
+var fragments = make(map[string]func())
+
+// includeStack tracks the absolute paths currently being spliced in so that
+// Include can detect a file including itself, directly or transitively.
+var includeStack []string
+
+// RegisterInclude associates a Go DSL fragment with the path of the file
+// that defines it so that other files can pull it in with Include. The path
+// is resolved relative to the caller, mirroring the resolution rules of
+// Include itself, and is typically called once per file at init time.
+//
+// Example:
+//
+//    func init() {
+//        dsl.RegisterInclude("payments.go", func() {
+//            SoftwareSystem("Payments", func() {
+//                Tag("team/payments")
+//            })
+//        })
+//    }
+//
+func RegisterInclude(path string, dsl func()) {
+	_, caller, _, _ := runtime.Caller(1)
+	fragments[resolve(filepath.Dir(caller), path)] = dsl
+}
+
+// Include splices the people, systems, containers, components,
+// relationships, deployment environments, views and styles defined by one or
+// more external DSL sources into the current Workspace. Each path may name a
+// Structurizr JSON workspace (".json") or a Go DSL file previously
+// registered with RegisterInclude, and is resolved relative to the directory
+// of the file calling Include. Glob patterns are supported so a single call
+// can pull in every file under a directory.
+//
+// People, systems (and their containers and components) and deployment
+// nodes are deduplicated by canonical name: when an included element
+// matches one already present in the workspace its tags and properties are
+// merged into the existing element rather than creating a duplicate.
+// Relationships are appended as-is: the expr model links a relationship to
+// its source and destination by ID rather than by name, so safely
+// deduplicating a relationship coming from a different workspace would
+// require remapping those IDs across the merge, which is not attempted
+// here; including the same fragment more than once can therefore duplicate
+// relationships even though it will not duplicate the elements themselves.
+// Views are appended. The styles of an included JSON workspace are used
+// only when the current workspace has no styles of its own; otherwise they
+// are dropped rather than merged.
+//
+// Including a path that is already being processed, directly or through a
+// chain of other includes, is reported as an error rather than causing
+// infinite recursion.
+//
+// Include must appear in a Workspace expression.
+//
+// Include accepts one or more paths.
+//
+// Example:
+//
+//    var _ = Workspace(func() {
+//        Include("shared/payments.json")
+//        Include("teams/*/landscape.go")
+//    })
+//
+func Include(path ...string) {
+	w, ok := eval.Current().(*expr.Workspace)
+	if !ok {
+		eval.IncompatibleDSL()
+		return
+	}
+	_, caller, _, _ := runtime.Caller(1)
+	base := filepath.Dir(caller)
+	for _, p := range path {
+		matches, err := filepath.Glob(resolve(base, p))
+		if err != nil {
+			eval.ReportError("Include: invalid pattern %q: %s", p, err.Error())
+			continue
+		}
+		if len(matches) == 0 {
+			matches = []string{resolve(base, p)}
+		}
+		for _, m := range matches {
+			includeOne(w, m)
+		}
+	}
+}
+
+// Extends loads the Structurizr JSON workspace at path and uses it as the
+// starting point for the enclosing Workspace: its Model, Views and
+// Configuration are copied into the current workspace before the rest of
+// the Workspace DSL runs. Once that DSL has finished running, reconcile
+// matches the elements it added against the base workspace by canonical
+// name: an element that already existed in the base has its description,
+// technology (for containers and components) and URL overridden by its
+// namesake in the overriding DSL, its namesake's tags appended to its own
+// (the base tags are kept, not replaced) and properties merged in, and the
+// duplicate removed, while a genuinely new name is left as a new element.
+// path is resolved relative to the directory of the file calling Extends.
+//
+// Extends must appear in a Workspace expression, and should be the first
+// expression in the DSL so that later elements are seen as overrides.
+//
+// Extends accepts exactly one argument: the path to the base workspace.
+//
+// Example:
+//
+//    var _ = Workspace(func() {
+//        Extends("landscape.json")
+//        SoftwareSystem("Payments", func() {
+//            Description("override of the base description")
+//        })
+//    })
+//
+func Extends(path string) {
+	w, ok := eval.Current().(*expr.Workspace)
+	if !ok {
+		eval.IncompatibleDSL()
+		return
+	}
+	_, caller, _, _ := runtime.Caller(1)
+	base, err := loadJSONWorkspace(resolve(filepath.Dir(caller), path))
+	if err != nil {
+		eval.ReportError("Extends: %s", err.Error())
+		return
+	}
+	w.Model = base.Model
+	w.Views = base.Views
+	w.Configuration = base.Configuration
+	markOverride(w)
+}
+
+// overrideWorkspaces remembers, for the lifetime of a single Workspace
+// build, which workspaces were started from Extends or Overlay and
+// therefore need the reconcile pass once their DSL finishes running.
+// Entries are removed as soon as they are consumed so the map cannot grow
+// across independently evaluated designs.
+var overrideWorkspaces = map[*expr.Workspace]bool{}
+
+// markOverride flags w as needing reconcile once its DSL has finished
+// running. Called by Extends and Overlay.
+func markOverride(w *expr.Workspace) {
+	overrideWorkspaces[w] = true
+}
+
+// reconcileIfOverride runs reconcile on w if it was started from Extends or
+// Overlay, then clears the flag. finalize calls this once the workspace's
+// DSL has fully run.
+func reconcileIfOverride(w *expr.Workspace) {
+	if !overrideWorkspaces[w] {
+		return
+	}
+	delete(overrideWorkspaces, w)
+	reconcile(w)
+}
+
+// reconcile merges elements that share a canonical name with one already
+// present earlier in the same slice, keeping the first (base) occurrence
+// and folding the later (override) occurrence into it: its description,
+// technology and URL replace the first occurrence's, and its tags and
+// properties are merged in. It is how Extends and Overlay implement "a later DSL statement
+// naming an existing element updates it in place" without needing to touch
+// the SoftwareSystem/Person/etc. constructors themselves.
+func reconcile(w *expr.Workspace) {
+	if w.Model == nil {
+		return
+	}
+	w.Model.People = mergePeople(nil, w.Model.People)
+	w.Model.Systems = mergeSystems(nil, w.Model.Systems)
+	w.Model.DeploymentNodes = mergeDeploymentNodes(nil, w.Model.DeploymentNodes)
+}
+
+// includeOne splices the DSL source or JSON workspace at the absolute path
+// abs into w, guarding against include cycles.
+func includeOne(w *expr.Workspace, abs string) {
+	if stackContains(includeStack, abs) {
+		eval.ReportError("Include: cycle detected, %q is already being included", abs)
+		return
+	}
+	includeStack = append(includeStack, abs)
+	defer func() { includeStack = includeStack[:len(includeStack)-1] }()
+
+	if filepath.Ext(abs) == ".json" {
+		included, err := loadJSONWorkspace(abs)
+		if err != nil {
+			eval.ReportError("Include: %s", err.Error())
+			return
+		}
+		mergeWorkspace(w, included)
+		return
+	}
+	fragment, ok := fragments[abs]
+	if !ok {
+		eval.ReportError("Include: no DSL registered for %q, call RegisterInclude from that file first", abs)
+		return
+	}
+	eval.Execute(fragment, w)
+}
+
+// resolve returns path as-is if it is already absolute, otherwise it joins
+// it onto base.
+func resolve(base, path string) string {
+	if filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(base, path)
+}
+
+// stackContains reports whether path is already present in stack, used to
+// detect an Include cycle before it recurses infinitely.
+func stackContains(stack []string, path string) bool {
+	for _, seen := range stack {
+		if seen == path {
+			return true
+		}
+	}
+	return false
+}
+
+// mergeWorkspace splices the model and views of src into dst, deduplicating
+// elements by canonical name and merging tags and properties on collision.
+// The styles of src are taken only when dst has none.
+func mergeWorkspace(dst, src *expr.Workspace) {
+	if src.Model != nil {
+		if dst.Model == nil {
+			dst.Model = &expr.Model{}
+		}
+		dst.Model.People = mergePeople(dst.Model.People, src.Model.People)
+		dst.Model.Systems = mergeSystems(dst.Model.Systems, src.Model.Systems)
+		dst.Model.DeploymentNodes = mergeDeploymentNodes(dst.Model.DeploymentNodes, src.Model.DeploymentNodes)
+		dst.Model.Relationships = append(dst.Model.Relationships, src.Model.Relationships...)
+	}
+	if src.Views != nil {
+		if dst.Views == nil {
+			dst.Views = src.Views
+		} else {
+			mergeViews(dst.Views, src.Views)
+		}
+	}
+}
+
+// mergePeople merges src into dst by canonical name: a name already present
+// has its description and URL overridden by its namesake in src, which also
+// has its tags appended and its properties merged in; a new name is
+// appended.
+func mergePeople(dst, src []*expr.Person) []*expr.Person {
+	byName := make(map[string]*expr.Person, len(dst))
+	for _, e := range dst {
+		byName[e.Name] = e
+	}
+	for _, s := range src {
+		if existing, ok := byName[s.Name]; ok {
+			existing.Description = s.Description
+			existing.URL = s.URL
+			existing.Tags = mergeTags(existing.Tags, s.Tags)
+			mergeProperties(&existing.Properties, s.Properties)
+			continue
+		}
+		dst = append(dst, s)
+		byName[s.Name] = s
+	}
+	return dst
+}
+
+// mergeSystems merges src into dst by canonical name, recursing into each
+// system's containers and components.
+func mergeSystems(dst, src []*expr.SoftwareSystem) []*expr.SoftwareSystem {
+	byName := make(map[string]*expr.SoftwareSystem, len(dst))
+	for _, e := range dst {
+		byName[e.Name] = e
+	}
+	for _, s := range src {
+		if existing, ok := byName[s.Name]; ok {
+			existing.Description = s.Description
+			existing.URL = s.URL
+			existing.Tags = mergeTags(existing.Tags, s.Tags)
+			mergeProperties(&existing.Properties, s.Properties)
+			existing.Containers = mergeContainers(existing.Containers, s.Containers)
+			continue
+		}
+		dst = append(dst, s)
+		byName[s.Name] = s
+	}
+	return dst
+}
+
+// mergeContainers merges src into dst by canonical name, recursing into
+// each container's components.
+func mergeContainers(dst, src []*expr.Container) []*expr.Container {
+	byName := make(map[string]*expr.Container, len(dst))
+	for _, e := range dst {
+		byName[e.Name] = e
+	}
+	for _, s := range src {
+		if existing, ok := byName[s.Name]; ok {
+			existing.Description = s.Description
+			existing.Technology = s.Technology
+			existing.URL = s.URL
+			existing.Tags = mergeTags(existing.Tags, s.Tags)
+			mergeProperties(&existing.Properties, s.Properties)
+			existing.Components = mergeComponents(existing.Components, s.Components)
+			continue
+		}
+		dst = append(dst, s)
+		byName[s.Name] = s
+	}
+	return dst
+}
+
+// mergeComponents merges src into dst by canonical name.
+func mergeComponents(dst, src []*expr.Component) []*expr.Component {
+	byName := make(map[string]*expr.Component, len(dst))
+	for _, e := range dst {
+		byName[e.Name] = e
+	}
+	for _, s := range src {
+		if existing, ok := byName[s.Name]; ok {
+			existing.Description = s.Description
+			existing.Technology = s.Technology
+			existing.URL = s.URL
+			existing.Tags = mergeTags(existing.Tags, s.Tags)
+			mergeProperties(&existing.Properties, s.Properties)
+			continue
+		}
+		dst = append(dst, s)
+		byName[s.Name] = s
+	}
+	return dst
+}
+
+// mergeDeploymentNodes merges src into dst by canonical name. Nested
+// infrastructure nodes and container instances are not deep-merged when a
+// deployment node collides: the incoming node's own description and URL
+// override the existing node's and its tags and properties are merged in,
+// but its children are ignored and the existing node keeps its own, which
+// is a narrower guarantee than the element merges above.
+func mergeDeploymentNodes(dst, src []*expr.DeploymentNode) []*expr.DeploymentNode {
+	byName := make(map[string]*expr.DeploymentNode, len(dst))
+	for _, e := range dst {
+		byName[e.Name] = e
+	}
+	for _, s := range src {
+		if existing, ok := byName[s.Name]; ok {
+			existing.Description = s.Description
+			existing.URL = s.URL
+			existing.Tags = mergeTags(existing.Tags, s.Tags)
+			mergeProperties(&existing.Properties, s.Properties)
+			continue
+		}
+		dst = append(dst, s)
+		byName[s.Name] = s
+	}
+	return dst
+}
+
+func mergeTags(existing, incoming string) string {
+	if existing == "" {
+		return incoming
+	}
+	if incoming == "" {
+		return existing
+	}
+	return existing + "," + incoming
+}
+
+func mergeProperties(dst *map[string]string, src map[string]string) {
+	if src == nil {
+		return
+	}
+	if *dst == nil {
+		*dst = make(map[string]string, len(src))
+	}
+	for k, v := range src {
+		(*dst)[k] = v
+	}
+}
+
+func mergeViews(dst, src *expr.Views) {
+	dst.SystemLandscapeViews = append(dst.SystemLandscapeViews, src.SystemLandscapeViews...)
+	dst.SystemContextViews = append(dst.SystemContextViews, src.SystemContextViews...)
+	dst.ContainerViews = append(dst.ContainerViews, src.ContainerViews...)
+	dst.ComponentViews = append(dst.ComponentViews, src.ComponentViews...)
+	if src.Styles != nil {
+		if dst.Styles == nil {
+			dst.Styles = src.Styles
+		}
+	}
+}
+
+// loadJSONWorkspace reads and unmarshals the Structurizr JSON workspace at
+// path into the fields expr.Workspace exposes. Anything the Structurizr JSON
+// schema carries that expr.Workspace does not model is dropped on load.
+func loadJSONWorkspace(path string) (*expr.Workspace, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load workspace %q: %s", path, err.Error())
+	}
+	defer f.Close()
+	w := &expr.Workspace{}
+	if err := json.NewDecoder(f).Decode(w); err != nil {
+		return nil, fmt.Errorf("failed to load workspace %q: %s", path, err.Error())
+	}
+	return w, nil
+}
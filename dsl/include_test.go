@@ -0,0 +1,142 @@
+package dsl
+
+import (
+	"testing"
+
+	"goa.design/structurizr/expr"
+)
+
+func TestResolve(t *testing.T) {
+	cases := []struct {
+		base, path, want string
+	}{
+		{"/a/b", "c.json", "/a/b/c.json"},
+		{"/a/b", "/c.json", "/c.json"},
+		{"/a/b", "../c.json", "/a/c.json"},
+	}
+	for _, c := range cases {
+		if got := resolve(c.base, c.path); got != c.want {
+			t.Errorf("resolve(%q, %q) = %q, want %q", c.base, c.path, got, c.want)
+		}
+	}
+}
+
+func TestStackContains(t *testing.T) {
+	stack := []string{"/a.json", "/b.json"}
+	if !stackContains(stack, "/a.json") {
+		t.Errorf("expected stack to contain /a.json")
+	}
+	if stackContains(stack, "/c.json") {
+		t.Errorf("did not expect stack to contain /c.json")
+	}
+}
+
+func TestMergeTags(t *testing.T) {
+	cases := []struct{ existing, incoming, want string }{
+		{"", "a,b", "a,b"},
+		{"a,b", "", "a,b"},
+		{"a", "b", "a,b"},
+	}
+	for _, c := range cases {
+		if got := mergeTags(c.existing, c.incoming); got != c.want {
+			t.Errorf("mergeTags(%q, %q) = %q, want %q", c.existing, c.incoming, got, c.want)
+		}
+	}
+}
+
+func TestMergeSystemsDedupesByName(t *testing.T) {
+	dst := []*expr.SoftwareSystem{
+		{Name: "Payments", Tags: "tier/critical", Properties: map[string]string{"owner": "team-a"}},
+	}
+	src := []*expr.SoftwareSystem{
+		{Name: "Payments", Tags: "team/payments", Properties: map[string]string{"cost_center": "123"}},
+		{Name: "Checkout", Tags: "tier/standard"},
+	}
+
+	got := mergeSystems(dst, src)
+
+	if len(got) != 2 {
+		t.Fatalf("expected 2 systems after merge, got %d", len(got))
+	}
+	payments := got[0]
+	if payments.Tags != "tier/critical,team/payments" {
+		t.Errorf("unexpected merged tags: %q", payments.Tags)
+	}
+	if payments.Properties["owner"] != "team-a" || payments.Properties["cost_center"] != "123" {
+		t.Errorf("expected properties to be merged, got %v", payments.Properties)
+	}
+	if got[1].Name != "Checkout" {
+		t.Errorf("expected new system Checkout to be appended, got %q", got[1].Name)
+	}
+}
+
+func TestMergeSystemsMergesContainers(t *testing.T) {
+	dst := []*expr.SoftwareSystem{
+		{Name: "Payments", Containers: []*expr.Container{{Name: "API", Tags: "tier/critical"}}},
+	}
+	src := []*expr.SoftwareSystem{
+		{Name: "Payments", Containers: []*expr.Container{
+			{Name: "API", Tags: "team/payments"},
+			{Name: "Worker"},
+		}},
+	}
+
+	got := mergeSystems(dst, src)
+
+	if len(got) != 1 {
+		t.Fatalf("expected Payments to stay a single system, got %d", len(got))
+	}
+	if len(got[0].Containers) != 2 {
+		t.Fatalf("expected 2 containers after merge, got %d", len(got[0].Containers))
+	}
+	if got[0].Containers[0].Tags != "tier/critical,team/payments" {
+		t.Errorf("unexpected merged container tags: %q", got[0].Containers[0].Tags)
+	}
+}
+
+func TestMergeSystemsOverridesDescriptionAndURL(t *testing.T) {
+	dst := []*expr.SoftwareSystem{
+		{Name: "Payments", Description: "base description", URL: "https://base"},
+	}
+	src := []*expr.SoftwareSystem{
+		{Name: "Payments", Description: "override description", URL: "https://override"},
+	}
+
+	got := mergeSystems(dst, src)
+
+	if got[0].Description != "override description" {
+		t.Errorf("Description = %q, want the override's description", got[0].Description)
+	}
+	if got[0].URL != "https://override" {
+		t.Errorf("URL = %q, want the override's URL", got[0].URL)
+	}
+}
+
+func TestMergeContainersOverridesTechnology(t *testing.T) {
+	dst := []*expr.Container{{Name: "API", Technology: "Go"}}
+	src := []*expr.Container{{Name: "API", Technology: "Go 1.22"}}
+
+	got := mergeContainers(dst, src)
+
+	if got[0].Technology != "Go 1.22" {
+		t.Errorf("Technology = %q, want the override's technology", got[0].Technology)
+	}
+}
+
+func TestReconcileFoldsOverrideIntoBase(t *testing.T) {
+	w := &expr.Workspace{Model: &expr.Model{
+		Systems: []*expr.SoftwareSystem{
+			{Name: "Payments", Tags: "tier/critical"},
+			{Name: "Payments", Tags: "team/payments", Properties: map[string]string{"cost_center": "123"}},
+		},
+	}}
+
+	reconcile(w)
+
+	if len(w.Model.Systems) != 1 {
+		t.Fatalf("expected the duplicate Payments system to be folded away, got %d systems", len(w.Model.Systems))
+	}
+	if w.Model.Systems[0].Tags != "tier/critical,team/payments" {
+		t.Errorf("unexpected merged tags: %q", w.Model.Systems[0].Tags)
+	}
+}
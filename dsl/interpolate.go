@@ -0,0 +1,154 @@
+package dsl
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"goa.design/goa/v3/eval"
+)
+
+// ResolvedVariable records how a single ${...} reference encountered while
+// building the workspace was resolved. The slice returned by
+// InterpolationManifest lets ops teams audit exactly what was baked into a
+// design from a build's environment.
+type ResolvedVariable struct {
+	// Name is the variable reference, e.g. "DB_HOST" or "vault:secret/db#pass".
+	Name string
+	// Source is the prefix that resolved the variable, "env" for a plain
+	// ${VAR} reference or the prefix registered with RegisterValueSource.
+	Source string
+	// DefaultUsed is true if the variable was unset and its ":-default"
+	// fallback was used instead.
+	DefaultUsed bool
+}
+
+// ValueSourceFunc resolves the key of a prefixed variable reference (the
+// part after "prefix:") to a value. The second return value indicates
+// whether the key was found; a false with a nil error means "unset" and is
+// treated the same as a missing environment variable.
+type ValueSourceFunc func(key string) (string, bool, error)
+
+var (
+	valueSources = make(map[string]ValueSourceFunc)
+	manifest     []ResolvedVariable
+)
+
+// interpolationRef matches a whole ${...} reference; splitRef then parses
+// its contents. The key (everything up to the first ":-" or ":?") routinely
+// contains colons of its own, e.g. "vault:secret/path#field", so splitting
+// the operator out of the same capture group that matches the key does not
+// work: a regex group cannot both be "as much as possible" to capture a
+// colon-bearing key and "as little as possible" to leave room for an
+// optional trailing operator.
+var interpolationRef = regexp.MustCompile(`\$\{([^}]*)\}`)
+
+// RegisterValueSource wires a provider for variable references of the form
+// ${prefix:key}, for example ${vault:secret/path#field} or
+// ${file:./versions.txt}. fn receives the part of the reference after the
+// colon and returns the resolved value.
+//
+// RegisterValueSource is typically called once at init time, before any DSL
+// that references the prefix runs.
+//
+// Example:
+//
+//    func init() {
+//        dsl.RegisterValueSource("vault", func(key string) (string, bool, error) {
+//            return vaultClient.Read(key)
+//        })
+//    }
+//
+func RegisterValueSource(prefix string, fn func(key string) (string, bool, error)) {
+	valueSources[prefix] = fn
+}
+
+// InterpolationManifest returns every variable reference resolved so far
+// while building the current workspace, in the order it was encountered.
+func InterpolationManifest() []ResolvedVariable {
+	return manifest
+}
+
+// ResetInterpolationManifest clears the manifest. It is called when a new
+// Workspace expression starts so that a manifest never leaks across designs
+// evaluated in the same process.
+func ResetInterpolationManifest() {
+	manifest = nil
+}
+
+// splitRef splits the content of a ${...} reference into its key and,
+// if present, its ":-default" or ":?message" operator and argument. The key
+// is everything before the first such operator, which may itself contain
+// colons (a "prefix:key" reference for a registered value source).
+func splitRef(inner string) (key, op, arg string) {
+	def := strings.Index(inner, ":-")
+	errAt := strings.Index(inner, ":?")
+	switch {
+	case def >= 0 && (errAt < 0 || def < errAt):
+		return inner[:def], ":-", inner[def+2:]
+	case errAt >= 0:
+		return inner[:errAt], ":?", inner[errAt+2:]
+	default:
+		return inner, "", ""
+	}
+}
+
+// resolveRef resolves a single ${...} reference's inner content using
+// lookup, returning the text it expands to, the manifest entry to record
+// (nil if none should be), and an error message to report if resolution
+// failed. It has no dependency on eval so it can be tested directly.
+func resolveRef(inner string, lookup func(key string) (value, source string, found bool, err error)) (output string, rv *ResolvedVariable, errMsg string) {
+	key, op, arg := splitRef(inner)
+	value, source, found, err := lookup(key)
+	if err != nil {
+		return "", nil, err.Error()
+	}
+	if found {
+		return value, &ResolvedVariable{Name: key, Source: source}, ""
+	}
+	switch op {
+	case ":-":
+		return arg, &ResolvedVariable{Name: key, Source: source, DefaultUsed: true}, ""
+	case ":?":
+		msg := arg
+		if msg == "" {
+			msg = fmt.Sprintf("%q is not set", key)
+		}
+		return "", nil, msg
+	default:
+		return "", nil, fmt.Sprintf("%q is not set", key)
+	}
+}
+
+// interpolate resolves every ${...} reference in s. Errors, including an
+// unset variable with a ":?message" requirement, are reported through
+// eval.ReportError with the offending reference so they surface at
+// design-eval time instead of producing a silently empty string.
+func interpolate(s string) string {
+	return interpolationRef.ReplaceAllStringFunc(s, func(ref string) string {
+		inner := ref[2 : len(ref)-1]
+		output, rv, errMsg := resolveRef(inner, lookupValue)
+		if errMsg != "" {
+			eval.ReportError("invalid interpolation %q: %s", ref, errMsg)
+			return ref
+		}
+		if rv != nil {
+			manifest = append(manifest, *rv)
+		}
+		return output
+	})
+}
+
+// lookupValue resolves key against a registered value source if it has a
+// "prefix:" form, falling back to the process environment otherwise.
+func lookupValue(key string) (value, source string, found bool, err error) {
+	for prefix, fn := range valueSources {
+		if strings.HasPrefix(key, prefix+":") {
+			value, found, err = fn(key[len(prefix)+1:])
+			return value, prefix, found, err
+		}
+	}
+	value, found = os.LookupEnv(key)
+	return value, "env", found, nil
+}
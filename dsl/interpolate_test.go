@@ -0,0 +1,83 @@
+package dsl
+
+import "testing"
+
+func TestSplitRef(t *testing.T) {
+	cases := []struct {
+		inner        string
+		key, op, arg string
+	}{
+		{"DB_HOST", "DB_HOST", "", ""},
+		{"DB_HOST:-localhost", "DB_HOST", ":-", "localhost"},
+		{"DB_HOST:?missing host", "DB_HOST", ":?", "missing host"},
+		{"vault:secret/path#field", "vault:secret/path#field", "", ""},
+		{"vault:secret/path#field:-fallback", "vault:secret/path#field", ":-", "fallback"},
+	}
+	for _, c := range cases {
+		key, op, arg := splitRef(c.inner)
+		if key != c.key || op != c.op || arg != c.arg {
+			t.Errorf("splitRef(%q) = (%q, %q, %q), want (%q, %q, %q)",
+				c.inner, key, op, arg, c.key, c.op, c.arg)
+		}
+	}
+}
+
+func TestResolveRefRegisteredPrefix(t *testing.T) {
+	// This is the regression case: before the fix, splitting the key on the
+	// first ":" meant lookupValue only ever saw "vault", never the part
+	// after the colon, so a registered "vault" source could never fire.
+	var gotKey string
+	lookup := func(key string) (string, string, bool, error) {
+		gotKey = key
+		return "s3cr3t", "vault", true, nil
+	}
+
+	output, rv, errMsg := resolveRef("vault:secret/path#field", lookup)
+
+	if errMsg != "" {
+		t.Fatalf("unexpected error: %s", errMsg)
+	}
+	if output != "s3cr3t" {
+		t.Errorf("output = %q, want %q", output, "s3cr3t")
+	}
+	if rv == nil || rv.Source != "vault" || rv.Name != "vault:secret/path#field" {
+		t.Errorf("unexpected manifest entry: %+v", rv)
+	}
+	if gotKey != "vault:secret/path#field" {
+		t.Errorf("lookup received key %q, want %q", gotKey, "vault:secret/path#field")
+	}
+}
+
+func TestLookupValueDispatchesToRegisteredPrefix(t *testing.T) {
+	var received string
+	RegisterValueSource("test-vault", func(key string) (string, bool, error) {
+		received = key
+		return "s3cr3t", true, nil
+	})
+	defer delete(valueSources, "test-vault")
+
+	value, source, found, err := lookupValue("test-vault:secret/path#field")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if !found || value != "s3cr3t" || source != "test-vault" {
+		t.Errorf("lookupValue = (%q, %q, %v), want (%q, %q, true)", value, source, found, "s3cr3t", "test-vault")
+	}
+	if received != "secret/path#field" {
+		t.Errorf("registered source received key %q, want %q", received, "secret/path#field")
+	}
+}
+
+func TestResolveRefDefaultAndRequired(t *testing.T) {
+	missing := func(string) (string, string, bool, error) { return "", "env", false, nil }
+
+	output, rv, errMsg := resolveRef("FOO:-fallback", missing)
+	if errMsg != "" || output != "fallback" || rv == nil || !rv.DefaultUsed {
+		t.Errorf("unexpected result for default: output=%q rv=%+v errMsg=%q", output, rv, errMsg)
+	}
+
+	_, rv, errMsg = resolveRef("FOO:?FOO is required", missing)
+	if errMsg != "FOO is required" || rv != nil {
+		t.Errorf("unexpected result for required: rv=%+v errMsg=%q", rv, errMsg)
+	}
+}
@@ -0,0 +1,99 @@
+package dsl
+
+import (
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"runtime"
+
+	"goa.design/goa/v3/eval"
+	"goa.design/structurizr/expr"
+)
+
+// LoadWorkspace reads the Structurizr JSON workspace at path and makes it
+// the current expr.Root, so that it can be incrementally migrated to the Go
+// DSL: Overlay then layers additional DSL on top of it, and WriteJSON can
+// write it back out. path is resolved relative to the directory of the file
+// calling LoadWorkspace. The round trip only goes through the fields
+// expr.Workspace itself models and marshals; any field of the Structurizr
+// JSON schema that expr.Workspace does not expose is lost, so this is not a
+// byte-for-byte round trip.
+//
+// LoadWorkspace is a top-level DSL function.
+//
+// LoadWorkspace accepts exactly one argument: the path to the workspace to
+// load.
+//
+// Example:
+//
+//    var _ = dsl.LoadWorkspace("legacy-workspace.json")
+//
+func LoadWorkspace(path string) *expr.Workspace {
+	_, ok := eval.Current().(eval.TopExpr)
+	if !ok {
+		eval.IncompatibleDSL()
+		return nil
+	}
+	_, caller, _, _ := runtime.Caller(1)
+	w, err := loadJSONWorkspace(resolve(filepath.Dir(caller), path))
+	if err != nil {
+		eval.ReportError("LoadWorkspace: %s", err.Error())
+		return nil
+	}
+	expr.Root = w
+	return w
+}
+
+// Overlay applies additional DSL on top of the workspace previously loaded
+// with LoadWorkspace. Like Extends, it marks the workspace for
+// reconciliation: once the overlay DSL has finished running, finalize folds
+// any person, software system or deployment node carrying the name of one
+// already present in the loaded tree into that existing element instead of
+// adding a duplicate.
+//
+// Overlay must be preceded by a call to LoadWorkspace in the same design.
+//
+// Overlay accepts a single argument: a function describing the changes to
+// apply.
+//
+// Example:
+//
+//    var _ = dsl.LoadWorkspace("legacy-workspace.json")
+//
+//    var _ = Overlay(func() {
+//        SoftwareSystem("Payments", func() {
+//            Tag("team/payments")
+//        })
+//    })
+//
+func Overlay(dsl func()) {
+	_, ok := eval.Current().(eval.TopExpr)
+	if !ok {
+		eval.IncompatibleDSL()
+		return
+	}
+	w := expr.Root
+	if w == nil {
+		eval.ReportError("Overlay: no workspace loaded, call LoadWorkspace first")
+		return
+	}
+	markOverride(w)
+	resetPropertyState()
+	resetTagState()
+	currentWorkspace = w
+	defer func() { currentWorkspace = nil }()
+	if !eval.Execute(dsl, w) {
+		return
+	}
+	finalize(w)
+}
+
+// WriteJSON serializes w as indented JSON to out. This is the write side of
+// the LoadWorkspace/Overlay round trip: it marshals whatever expr.Workspace
+// itself exposes, so it does not reproduce fields of the Structurizr JSON
+// schema that expr.Workspace does not model.
+func WriteJSON(w *expr.Workspace, out io.Writer) error {
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(w)
+}
@@ -0,0 +1,47 @@
+package dsl
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"goa.design/structurizr/expr"
+)
+
+func TestLoadJSONWorkspaceRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "workspace.json")
+	want := &expr.Workspace{Name: "Test", Description: "A workspace", Model: &expr.Model{}}
+
+	var buf bytes.Buffer
+	if err := WriteJSON(want, &buf); err != nil {
+		t.Fatalf("WriteJSON failed: %s", err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatalf("failed to write fixture: %s", err)
+	}
+
+	got, err := loadJSONWorkspace(path)
+	if err != nil {
+		t.Fatalf("loadJSONWorkspace failed: %s", err)
+	}
+	if got.Name != want.Name || got.Description != want.Description {
+		t.Errorf("loadJSONWorkspace = %+v, want %+v", got, want)
+	}
+}
+
+func TestWriteJSONProducesValidJSON(t *testing.T) {
+	w := &expr.Workspace{Name: "Test", Model: &expr.Model{}}
+
+	var buf bytes.Buffer
+	if err := WriteJSON(w, &buf); err != nil {
+		t.Fatalf("WriteJSON failed: %s", err)
+	}
+
+	var roundTripped map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &roundTripped); err != nil {
+		t.Fatalf("output is not valid JSON: %s", err)
+	}
+}
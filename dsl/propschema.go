@@ -0,0 +1,324 @@
+package dsl
+
+import (
+	"fmt"
+
+	"goa.design/goa/v3/eval"
+	"goa.design/structurizr/expr"
+)
+
+// PropType identifies the declared type of a property defined with PropDef.
+type PropType int
+
+const (
+	// String is the default property type, matching the existing free-form
+	// Prop DSL.
+	String PropType = iota
+	// Int declares a property whose value must be an integer.
+	Int
+	// Float declares a property whose value must be a floating point number.
+	Float
+	// Bool declares a property whose value must be true or false.
+	Bool
+	// StringList declares a property whose value is a list of strings.
+	StringList
+)
+
+// propertyDef is one entry declared in a PropSchema expression. It is kept
+// internal to this package rather than added to expr.Workspace: nothing
+// outside dsl needs to see a schema, only PropDef/PropInt/.../validateProperties
+// do.
+type propertyDef struct {
+	name     string
+	typ      PropType
+	required bool
+	min, max *float64
+}
+
+// PropOption refines a PropDef declaration.
+type PropOption func(*propertyDef)
+
+// Required marks a declared property as mandatory: finalize reports an error
+// for any element that never sets it.
+func Required() PropOption {
+	return func(d *propertyDef) { d.required = true }
+}
+
+// Min sets the minimum accepted value for an Int or Float property.
+func Min(v float64) PropOption {
+	return func(d *propertyDef) { d.min = &v }
+}
+
+// Max sets the maximum accepted value for an Int or Float property.
+func Max(v float64) PropOption {
+	return func(d *propertyDef) { d.max = &v }
+}
+
+// currentPropertySchema and typedProps hold the PropSchema declaration and
+// the typed property values set via PropInt/PropBool/PropFloat/PropList for
+// the workspace currently being built. typedProps is keyed by the element
+// pointer itself (e.g. a *expr.SoftwareSystem) since expr's element types
+// have no typed-properties field of their own to hold it. Both are reset by
+// resetPropertyState, which Workspace and Overlay call before running their
+// DSL so that state from one evaluated design never leaks into the next.
+var (
+	currentPropertySchema = make(map[string]*propertyDef)
+	typedProps            = make(map[interface{}]map[string]interface{})
+)
+
+// resetPropertyState clears the PropSchema declaration and every typed
+// property value recorded so far.
+func resetPropertyState() {
+	currentPropertySchema = make(map[string]*propertyDef)
+	typedProps = make(map[interface{}]map[string]interface{})
+}
+
+// PropSchema declares the set of properties that may be set on elements via
+// PropInt, PropBool, PropList and PropFloat. Once a schema is declared,
+// setting a property that is not listed in it, or one whose value does not
+// satisfy its declared type and range, is reported as an error when the
+// workspace is finalized.
+//
+// PropSchema must appear in a Workspace expression.
+//
+// PropSchema accepts a single argument: a function listing each property
+// using PropDef.
+//
+// Example:
+//
+//    var _ = Workspace(func() {
+//        PropSchema(func() {
+//            PropDef("cost_center", String, Required())
+//            PropDef("replicas", Int, Min(1), Max(100))
+//            PropDef("owners", StringList)
+//            PropDef("critical", Bool)
+//        })
+//    })
+//
+func PropSchema(dsl func()) {
+	w, ok := eval.Current().(*expr.Workspace)
+	if !ok {
+		eval.IncompatibleDSL()
+		return
+	}
+	eval.Execute(dsl, w)
+}
+
+// PropDef declares a single property in a PropSchema expression.
+//
+// PropDef must appear in a PropSchema expression.
+//
+// PropDef accepts the property name, its type and any number of options
+// (Required, Min, Max).
+func PropDef(name string, typ PropType, opts ...PropOption) {
+	if _, ok := eval.Current().(*expr.Workspace); !ok {
+		eval.IncompatibleDSL()
+		return
+	}
+	d := &propertyDef{name: name, typ: typ}
+	for _, opt := range opts {
+		opt(d)
+	}
+	currentPropertySchema[name] = d
+}
+
+// PropInt sets a typed integer property on the current element.
+//
+// PropInt may appear in Person, SoftwareSystem, Container, Component,
+// DeploymentNode, InfrastructureNode or ContainerInstance.
+func PropInt(name string, v int) {
+	setTypedProp(name, v)
+}
+
+// PropBool sets a typed boolean property on the current element.
+//
+// PropBool may appear in Person, SoftwareSystem, Container, Component,
+// DeploymentNode, InfrastructureNode or ContainerInstance.
+func PropBool(name string, v bool) {
+	setTypedProp(name, v)
+}
+
+// PropFloat sets a typed floating point property on the current element.
+//
+// PropFloat may appear in Person, SoftwareSystem, Container, Component,
+// DeploymentNode, InfrastructureNode or ContainerInstance.
+func PropFloat(name string, v float64) {
+	setTypedProp(name, v)
+}
+
+// PropList sets a typed string list property on the current element.
+//
+// PropList may appear in Person, SoftwareSystem, Container, Component,
+// DeploymentNode, InfrastructureNode or ContainerInstance.
+func PropList(name string, v ...string) {
+	setTypedProp(name, v)
+}
+
+func setTypedProp(name string, v interface{}) {
+	e := eval.Current()
+	switch e.(type) {
+	case *expr.Person, *expr.SoftwareSystem, *expr.Container, *expr.Component,
+		*expr.DeploymentNode, *expr.InfrastructureNode, *expr.ContainerInstance:
+	default:
+		eval.IncompatibleDSL()
+		return
+	}
+	props := typedProps[e]
+	if props == nil {
+		props = make(map[string]interface{})
+		typedProps[e] = props
+	}
+	props[name] = v
+}
+
+// TypedProperties returns the typed properties set on e via PropInt, PropBool,
+// PropFloat or PropList, keyed by property name. It returns nil if e never set
+// any. Callers such as a view or a cost/ownership report use this to read
+// back the values PropSchema validates at build time.
+func TypedProperties(e interface{}) map[string]interface{} {
+	return typedProps[e]
+}
+
+// elementName returns the Name of e for use in validation messages.
+// ContainerInstance has no name of its own in the Structurizr model (it
+// references a Container by ID), so it is reported with the generic label
+// "container instance" instead.
+func elementName(e interface{}) string {
+	switch v := e.(type) {
+	case *expr.Person:
+		return v.Name
+	case *expr.SoftwareSystem:
+		return v.Name
+	case *expr.Container:
+		return v.Name
+	case *expr.Component:
+		return v.Name
+	case *expr.DeploymentNode:
+		return v.Name
+	case *expr.InfrastructureNode:
+		return v.Name
+	case *expr.ContainerInstance:
+		return "container instance"
+	default:
+		return "unknown element"
+	}
+}
+
+// walkElements calls fn with every person, system, container, component,
+// deployment node, infrastructure node and container instance in w. It is
+// shared by validateProperties here and by ElementsByTag in taxonomy.go so
+// that both see the same, complete element tree.
+func walkElements(w *expr.Workspace, fn func(e interface{})) {
+	if w == nil || w.Model == nil {
+		return
+	}
+	for _, p := range w.Model.People {
+		fn(p)
+	}
+	for _, s := range w.Model.Systems {
+		fn(s)
+		for _, c := range s.Containers {
+			fn(c)
+			for _, cp := range c.Components {
+				fn(cp)
+			}
+		}
+	}
+	var walkDeploymentNode func(n *expr.DeploymentNode)
+	walkDeploymentNode = func(n *expr.DeploymentNode) {
+		fn(n)
+		for _, in := range n.InfrastructureNodes {
+			fn(in)
+		}
+		for _, ci := range n.ContainerInstances {
+			fn(ci)
+		}
+		for _, child := range n.Children {
+			walkDeploymentNode(child)
+		}
+	}
+	for _, n := range w.Model.DeploymentNodes {
+		walkDeploymentNode(n)
+	}
+}
+
+// validateProperties checks every element in w against currentPropertySchema,
+// reporting unknown keys, missing required properties and type or range
+// violations. Every element in the model is visited, not just the ones that
+// happen to have set a typed property, so that a required property that was
+// simply never set is still caught.
+func validateProperties(w *expr.Workspace) {
+	if len(currentPropertySchema) == 0 {
+		return
+	}
+	walkElements(w, func(e interface{}) {
+		for _, msg := range checkElementProperties(elementName(e), currentPropertySchema, typedProps[e]) {
+			eval.ReportError(msg)
+		}
+	})
+}
+
+// checkElementProperties compares props, the typed properties set on the
+// element named elem, against schema, returning one message per violation: an
+// unknown key, a missing required property, or a type or range mismatch. It
+// has no dependency on eval so it can be tested directly.
+func checkElementProperties(elem string, schema map[string]*propertyDef, props map[string]interface{}) []string {
+	var errs []string
+	for name := range props {
+		if _, ok := schema[name]; !ok {
+			errs = append(errs, fmt.Sprintf("%s: unknown property %q, it is not declared in PropSchema", elem, name))
+		}
+	}
+	for name, def := range schema {
+		v, set := props[name]
+		if !set {
+			if def.required {
+				errs = append(errs, fmt.Sprintf("%s: missing required property %q", elem, name))
+			}
+			continue
+		}
+		errs = append(errs, checkPropertyValue(elem, name, def, v)...)
+	}
+	return errs
+}
+
+func checkPropertyValue(elem, name string, def *propertyDef, v interface{}) []string {
+	switch def.typ {
+	case Int:
+		n, ok := v.(int)
+		if !ok {
+			return []string{fmt.Sprintf("%s: property %q must be an int", elem, name)}
+		}
+		return checkRange(elem, name, def, float64(n))
+	case Float:
+		f, ok := v.(float64)
+		if !ok {
+			return []string{fmt.Sprintf("%s: property %q must be a float", elem, name)}
+		}
+		return checkRange(elem, name, def, f)
+	case Bool:
+		if _, ok := v.(bool); !ok {
+			return []string{fmt.Sprintf("%s: property %q must be a bool", elem, name)}
+		}
+	case StringList:
+		if _, ok := v.([]string); !ok {
+			return []string{fmt.Sprintf("%s: property %q must be a list of strings", elem, name)}
+		}
+	default:
+		if _, ok := v.(string); !ok {
+			return []string{fmt.Sprintf("%s: property %q must be a string", elem, name)}
+		}
+	}
+	return nil
+}
+
+func checkRange(elem, name string, def *propertyDef, v float64) []string {
+	var errs []string
+	if def.min != nil && v < *def.min {
+		errs = append(errs, fmt.Sprintf("%s: property %q is below its minimum of %v", elem, name, *def.min))
+	}
+	if def.max != nil && v > *def.max {
+		errs = append(errs, fmt.Sprintf("%s: property %q is above its maximum of %v", elem, name, *def.max))
+	}
+	return errs
+}
@@ -0,0 +1,119 @@
+package dsl
+
+import (
+	"strings"
+	"testing"
+
+	"goa.design/structurizr/expr"
+)
+
+func TestCheckElementPropertiesRequiredMissing(t *testing.T) {
+	schema := map[string]*propertyDef{
+		"cost_center": {name: "cost_center", typ: String, required: true},
+	}
+	errs := checkElementProperties("Payments", schema, nil)
+	if len(errs) != 1 {
+		t.Fatalf("expected 1 error for a missing required property, got %v", errs)
+	}
+	if !strings.Contains(errs[0], "Payments") {
+		t.Errorf("expected error to name the offending element, got %q", errs[0])
+	}
+}
+
+func TestCheckElementPropertiesUnknownKey(t *testing.T) {
+	schema := map[string]*propertyDef{}
+	errs := checkElementProperties("Payments", schema, map[string]interface{}{"replicas": 3})
+	if len(errs) != 1 {
+		t.Fatalf("expected 1 error for an undeclared property, got %v", errs)
+	}
+	if !strings.Contains(errs[0], "Payments") {
+		t.Errorf("expected error to name the offending element, got %q", errs[0])
+	}
+}
+
+func TestCheckPropertyValueTypeAndRange(t *testing.T) {
+	min, max := 1.0, 100.0
+	def := &propertyDef{name: "replicas", typ: Int, min: &min, max: &max}
+
+	if errs := checkPropertyValue("Payments", "replicas", def, "not-an-int"); len(errs) != 1 {
+		t.Errorf("expected a type error, got %v", errs)
+	}
+	if errs := checkPropertyValue("Payments", "replicas", def, 0); len(errs) != 1 {
+		t.Errorf("expected a range error below the minimum, got %v", errs)
+	}
+	if errs := checkPropertyValue("Payments", "replicas", def, 200); len(errs) != 1 {
+		t.Errorf("expected a range error above the maximum, got %v", errs)
+	}
+	if errs := checkPropertyValue("Payments", "replicas", def, 5); len(errs) != 0 {
+		t.Errorf("expected no errors for a value in range, got %v", errs)
+	}
+	if errs := checkPropertyValue("Payments", "replicas", def, 0); !strings.Contains(errs[0], "Payments") {
+		t.Errorf("expected error to name the offending element, got %q", errs[0])
+	}
+}
+
+func TestCheckElementPropertiesDistinguishesElements(t *testing.T) {
+	schema := map[string]*propertyDef{
+		"cost_center": {name: "cost_center", typ: String, required: true},
+	}
+	payments := checkElementProperties("Payments", schema, nil)
+	checkout := checkElementProperties("Checkout", schema, nil)
+	if payments[0] == checkout[0] {
+		t.Errorf("expected distinct messages per element, both were %q", payments[0])
+	}
+}
+
+func TestTypedPropertiesReturnsWhatWasSet(t *testing.T) {
+	resetPropertyState()
+	e := &expr.SoftwareSystem{Name: "Payments"}
+	typedProps[e] = map[string]interface{}{"replicas": 3}
+
+	got := TypedProperties(e)
+
+	if got["replicas"] != 3 {
+		t.Errorf("TypedProperties(e) = %v, want replicas=3", got)
+	}
+}
+
+func TestTypedPropertiesNilWhenNeverSet(t *testing.T) {
+	resetPropertyState()
+	e := &expr.SoftwareSystem{Name: "Payments"}
+
+	if got := TypedProperties(e); got != nil {
+		t.Errorf("TypedProperties(e) = %v, want nil", got)
+	}
+}
+
+func TestWalkElementsVisitsWholeTree(t *testing.T) {
+	component := &expr.Component{Name: "Component"}
+	container := &expr.Container{Name: "Container", Components: []*expr.Component{component}}
+	system := &expr.SoftwareSystem{Name: "System", Containers: []*expr.Container{container}}
+	person := &expr.Person{Name: "Person"}
+	infra := &expr.InfrastructureNode{Name: "Infra"}
+	instance := &expr.ContainerInstance{}
+	child := &expr.DeploymentNode{Name: "Child"}
+	node := &expr.DeploymentNode{
+		Name:                "Node",
+		InfrastructureNodes: []*expr.InfrastructureNode{infra},
+		ContainerInstances:  []*expr.ContainerInstance{instance},
+		Children:            []*expr.DeploymentNode{child},
+	}
+	w := &expr.Workspace{Model: &expr.Model{
+		People:          []*expr.Person{person},
+		Systems:         []*expr.SoftwareSystem{system},
+		DeploymentNodes: []*expr.DeploymentNode{node},
+	}}
+
+	var visited []interface{}
+	walkElements(w, func(e interface{}) { visited = append(visited, e) })
+
+	want := []interface{}{person, system, container, component, node, infra, instance, child}
+	if len(visited) != len(want) {
+		t.Fatalf("visited %d elements, want %d: %v", len(visited), len(want), visited)
+	}
+	for i, e := range want {
+		if visited[i] != e {
+			t.Errorf("visited[%d] = %v, want %v", i, visited[i], e)
+		}
+	}
+}
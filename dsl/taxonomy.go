@@ -0,0 +1,214 @@
+package dsl
+
+import (
+	"strings"
+
+	"goa.design/goa/v3/eval"
+	"goa.design/structurizr/expr"
+)
+
+// tagDef is one entry declared in a Taxonomy expression. It is kept internal
+// to this package rather than added to expr.Workspace, the same choice made
+// for propertyDef in propschema.go.
+type tagDef struct {
+	name        string
+	color       string
+	description string
+	wildcard    bool
+}
+
+// TagOption refines a TagDef declaration.
+type TagOption func(*tagDef)
+
+// Color sets the display color associated with a declared tag, typically
+// used by diagram renderers that support per-tag styling.
+func Color(c string) TagOption {
+	return func(t *tagDef) { t.color = c }
+}
+
+// Description documents what a declared tag means.
+func Description(d string) TagOption {
+	return func(t *tagDef) { t.description = d }
+}
+
+// Wildcard marks a tag declaration as a prefix pattern, e.g. "tier/*", that
+// matches any tag sharing its namespace instead of requiring an exact match.
+func Wildcard() TagOption {
+	return func(t *tagDef) { t.wildcard = true }
+}
+
+// currentTaxonomy, usedTags and elementTags hold the Taxonomy declaration,
+// which declared tags have actually been applied, and the structured tag
+// list for each element (expr's element types only carry the legacy
+// comma-joined Tags string, so the per-tag list Tag builds lives here
+// instead). All three are reset by resetTagState, which Workspace and
+// Overlay call before running their DSL.
+var (
+	currentTaxonomy = make(map[string]*tagDef)
+	usedTags        = make(map[string]bool)
+	elementTags     = make(map[interface{}][]string)
+)
+
+// resetTagState clears the Taxonomy declaration, the used-tags bookkeeping
+// and every element's structured tag list.
+func resetTagState() {
+	currentTaxonomy = make(map[string]*tagDef)
+	usedTags = make(map[string]bool)
+	elementTags = make(map[interface{}][]string)
+}
+
+// Taxonomy declares the set of tags that Tag accepts for the enclosing
+// workspace. Once a Taxonomy is declared, Tag rejects any value that does
+// not match a TagDef (exactly, or via a Wildcard prefix).
+//
+// Taxonomy must appear in a Workspace expression.
+//
+// Taxonomy accepts a single argument: a function listing each tag using
+// TagDef.
+//
+// Example:
+//
+//    var _ = Workspace(func() {
+//        Taxonomy(func() {
+//            TagDef("team/payments", Color("#ff8800"), Description("Payments squad"))
+//            TagDef("tier/*", Wildcard())
+//        })
+//    })
+//
+func Taxonomy(dsl func()) {
+	w, ok := eval.Current().(*expr.Workspace)
+	if !ok {
+		eval.IncompatibleDSL()
+		return
+	}
+	eval.Execute(dsl, w)
+}
+
+// TagDef declares a single allowed tag in a Taxonomy expression.
+//
+// TagDef must appear in a Taxonomy expression.
+//
+// TagDef accepts the tag name and any number of options (Color, Description,
+// Wildcard).
+func TagDef(name string, opts ...TagOption) {
+	if _, ok := eval.Current().(*expr.Workspace); !ok {
+		eval.IncompatibleDSL()
+		return
+	}
+	d := &tagDef{name: name}
+	for _, opt := range opts {
+		opt(d)
+	}
+	currentTaxonomy[name] = d
+}
+
+// normalizeTag trims surrounding whitespace and lower-cases a tag value so
+// that e.g. "Team/Payments" and "team/payments " are treated as the same
+// tag.
+func normalizeTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
+
+// validateTag checks tag against currentTaxonomy, if one has been declared,
+// reporting an error and returning false if it is not an allowed value.
+func validateTag(tag string) bool {
+	if len(currentTaxonomy) == 0 {
+		return true
+	}
+	if _, ok := currentTaxonomy[tag]; ok {
+		usedTags[tag] = true
+		return true
+	}
+	ns, _, hasNS := strings.Cut(tag, "/")
+	if hasNS {
+		if def, ok := currentTaxonomy[ns+"/*"]; ok && def.wildcard {
+			usedTags[tag] = true
+			return true
+		}
+	}
+	eval.ReportError("tag %q is not declared in the workspace Taxonomy", tag)
+	return false
+}
+
+// appendTags adds values to both the comma-joined Tags string (kept for
+// Structurizr JSON output) and e's entry in the elementTags side table.
+func appendTags(e interface{}, csv string, values []string) string {
+	for _, v := range values {
+		if csv == "" {
+			csv = v
+		} else {
+			csv = csv + "," + v
+		}
+	}
+	elementTags[e] = append(elementTags[e], values...)
+	return csv
+}
+
+// ElementsByTag returns every element of w whose tags satisfy selector.
+// selector is a space-separated expression combining tag values with AND, OR
+// and NOT, evaluated left to right, e.g. "team/payments AND tier/critical"
+// or "team/payments OR team/checkout NOT tier/deprecated". Only tags applied
+// through Tag are matched: tags an element carries in its Tags string from a
+// loaded or included JSON workspace are not seen.
+func ElementsByTag(w *expr.Workspace, selector string) []interface{} {
+	tokens := strings.Fields(selector)
+	if len(tokens) == 0 {
+		return nil
+	}
+	var matches []interface{}
+	walkElements(w, func(e interface{}) {
+		if matchesSelector(elementTags[e], tokens) {
+			matches = append(matches, e)
+		}
+	})
+	return matches
+}
+
+// matchesSelector evaluates tokens, a sequence of tag values separated by
+// AND, OR and NOT, against tags, left to right.
+func matchesSelector(tags []string, tokens []string) bool {
+	has := func(tag string) bool {
+		for _, t := range tags {
+			if t == tag {
+				return true
+			}
+		}
+		return false
+	}
+	result := has(tokens[0])
+	op := ""
+	for _, tok := range tokens[1:] {
+		switch tok {
+		case "AND", "OR", "NOT":
+			op = tok
+			continue
+		}
+		v := has(tok)
+		switch op {
+		case "AND":
+			result = result && v
+		case "OR":
+			result = result || v
+		case "NOT":
+			result = result && !v
+		}
+	}
+	return result
+}
+
+// UnusedTags returns every tag declared in the workspace Taxonomy that was
+// never applied to an element via Tag, excluding wildcard entries (which are
+// declarations of a namespace, not a tag in their own right). Unlike
+// validateTag this is advisory, not a build failure: callers that want a
+// linter can call it after the workspace is built (e.g. from their codegen
+// or CI step) and decide what to do with the result themselves, rather than
+// this package printing to a global log on every build.
+func UnusedTags() []string {
+	var unused []string
+	for name := range currentTaxonomy {
+		if !usedTags[name] && !strings.HasSuffix(name, "/*") {
+			unused = append(unused, name)
+		}
+	}
+	return unused
+}
@@ -0,0 +1,82 @@
+package dsl
+
+import (
+	"testing"
+
+	"goa.design/structurizr/expr"
+)
+
+func TestValidateTagAcceptsWildcardNamespace(t *testing.T) {
+	resetTagState()
+	currentTaxonomy["tier/*"] = &tagDef{name: "tier/*", wildcard: true}
+
+	if !validateTag("tier/critical") {
+		t.Errorf("expected tier/critical to match the tier/* wildcard declaration")
+	}
+	if !usedTags["tier/critical"] {
+		t.Errorf("expected tier/critical to be recorded as used")
+	}
+}
+
+func TestValidateTagWithNoTaxonomyAcceptsAnything(t *testing.T) {
+	resetTagState()
+
+	if !validateTag("anything") {
+		t.Errorf("expected any tag to be accepted when no Taxonomy is declared")
+	}
+}
+
+func TestAppendTagsPopulatesElementTags(t *testing.T) {
+	resetTagState()
+	e := &expr.SoftwareSystem{Name: "System"}
+
+	csv := appendTags(e, "", []string{"a", "b"})
+
+	if csv != "a,b" {
+		t.Errorf("csv = %q, want %q", csv, "a,b")
+	}
+	if got := elementTags[e]; len(got) != 2 || got[0] != "a" || got[1] != "b" {
+		t.Errorf("elementTags[e] = %v, want [a b]", got)
+	}
+}
+
+func TestElementsByTagFindsTaggedDeploymentNode(t *testing.T) {
+	resetTagState()
+	node := &expr.DeploymentNode{Name: "Node"}
+	appendTags(node, node.Tags, []string{"tier/critical"})
+	w := &expr.Workspace{Model: &expr.Model{DeploymentNodes: []*expr.DeploymentNode{node}}}
+
+	matches := ElementsByTag(w, "tier/critical")
+
+	if len(matches) != 1 || matches[0] != node {
+		t.Errorf("ElementsByTag = %v, want [%v]", matches, node)
+	}
+}
+
+func TestMatchesSelectorOperators(t *testing.T) {
+	tags := []string{"team/payments", "tier/critical"}
+
+	if !matchesSelector(tags, []string{"team/payments", "AND", "tier/critical"}) {
+		t.Errorf("expected AND of two present tags to match")
+	}
+	if matchesSelector(tags, []string{"team/payments", "NOT", "tier/critical"}) {
+		t.Errorf("expected NOT to exclude an element carrying the excluded tag")
+	}
+	if !matchesSelector(tags, []string{"team/checkout", "OR", "team/payments"}) {
+		t.Errorf("expected OR to match when the second tag is present")
+	}
+}
+
+func TestUnusedTagsExcludesWildcardAndUsedTags(t *testing.T) {
+	resetTagState()
+	currentTaxonomy["team/payments"] = &tagDef{name: "team/payments"}
+	currentTaxonomy["team/checkout"] = &tagDef{name: "team/checkout"}
+	currentTaxonomy["tier/*"] = &tagDef{name: "tier/*", wildcard: true}
+	usedTags["team/payments"] = true
+
+	unused := UnusedTags()
+
+	if len(unused) != 1 || unused[0] != "team/checkout" {
+		t.Errorf("UnusedTags() = %v, want [team/checkout]", unused)
+	}
+}
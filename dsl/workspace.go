@@ -2,7 +2,6 @@ package dsl
 
 import (
 	"net/url"
-	"strings"
 
 	"goa.design/goa/v3/eval"
 	"goa.design/structurizr/expr"
@@ -80,13 +79,35 @@ func Workspace(args ...interface{}) {
 		eval.ReportError("too many arguments")
 		return
 	}
-	w := &expr.Workspace{Name: name, Description: desc, Model: &expr.Model{}}
+	ResetInterpolationManifest()
+	resetPropertyState()
+	resetTagState()
+	w := &expr.Workspace{Name: interpolate(name), Description: interpolate(desc), Model: &expr.Model{}}
+	currentWorkspace = w
+	defer func() { currentWorkspace = nil }()
 	if !eval.Execute(dsl, w) {
 		return
 	}
+	finalize(w)
 	expr.Root = w
 }
 
+// currentWorkspace is the workspace currently being built by Workspace or
+// Overlay; it is cleared once their DSL has run. Nothing in this package
+// reads it: Tag and the typed property functions look up workspace-level
+// declarations through currentTaxonomy and currentPropertySchema.
+var currentWorkspace *expr.Workspace
+
+// finalize runs the steps that need the fully built workspace: a workspace
+// started from Extends or Overlay has its overriding elements folded into
+// their base-workspace namesakes, and typed properties are checked against
+// any declared PropSchema. Tags declared in a Taxonomy but never applied to
+// an element are not reported here; call UnusedTags if that check is wanted.
+func finalize(w *expr.Workspace) {
+	reconcileIfOverride(w)
+	validateProperties(w)
+}
+
 // Version specifies a version number for the workspace.
 //
 // Version must appear in a Workspace expression.
@@ -104,7 +125,7 @@ func Version(v string) {
 	if !ok {
 		eval.IncompatibleDSL()
 	} else {
-		w.Version = v
+		w.Version = interpolate(v)
 	}
 }
 
@@ -127,12 +148,17 @@ func Enterprise(e string) {
 	if !ok {
 		eval.IncompatibleDSL()
 	} else {
-		w.Model.Enterprise = &expr.Enterprise{Name: e}
+		w.Model.Enterprise = &expr.Enterprise{Name: interpolate(e)}
 	}
 }
 
 // Tag defines a set of tags on the given element. Tags are used in views to
 // identify group of elements that should be rendered together for example.
+// Each value is interpolated, then normalized (trimmed and lower-cased) and,
+// if the enclosing workspace declares a Taxonomy, validated against it: a
+// tag that matches no TagDef, exactly or through a Wildcard namespace, is
+// reported as an error and not added. No "namespace/value" form is enforced
+// beyond what the declared TagDef entries match.
 //
 // Tag may appear in Person, SoftwareSystem, Container, Component,
 // DeploymentNode, InfrastructureNode, ContainerInstance.
@@ -150,31 +176,28 @@ func Enterprise(e string) {
 //    })
 //
 func Tag(first string, t ...string) {
-	tags := first
-	if len(t) > 0 {
-		tags = tags + "," + strings.Join(t, ",")
-	}
-	setOrAppend := func(exist, new string) string {
-		if exist == "" {
-			return new
+	values := make([]string, 0, len(t)+1)
+	for _, v := range append([]string{first}, t...) {
+		v = normalizeTag(interpolate(v))
+		if validateTag(v) {
+			values = append(values, v)
 		}
-		return exist + "," + new
 	}
 	switch e := eval.Current().(type) {
 	case *expr.Person:
-		e.Tags = setOrAppend(e.Tags, tags)
+		e.Tags = appendTags(e, e.Tags, values)
 	case *expr.SoftwareSystem:
-		e.Tags = setOrAppend(e.Tags, tags)
+		e.Tags = appendTags(e, e.Tags, values)
 	case *expr.Container:
-		e.Tags = setOrAppend(e.Tags, tags)
+		e.Tags = appendTags(e, e.Tags, values)
 	case *expr.Component:
-		e.Tags = setOrAppend(e.Tags, tags)
+		e.Tags = appendTags(e, e.Tags, values)
 	case *expr.DeploymentNode:
-		e.Tags = setOrAppend(e.Tags, tags)
+		e.Tags = appendTags(e, e.Tags, values)
 	case *expr.InfrastructureNode:
-		e.Tags = setOrAppend(e.Tags, tags)
+		e.Tags = appendTags(e, e.Tags, values)
 	case *expr.ContainerInstance:
-		e.Tags = setOrAppend(e.Tags, tags)
+		e.Tags = appendTags(e, e.Tags, values)
 	default:
 		eval.IncompatibleDSL()
 	}
@@ -197,6 +220,7 @@ func Tag(first string, t ...string) {
 //    })
 //
 func URL(u string) {
+	u = interpolate(u)
 	if _, err := url.Parse(u); err != nil {
 		eval.ReportError("invalid URL %q: %s", u, err.Error())
 	}
@@ -316,6 +340,7 @@ func Properties(dsl func()) {
 //    })
 //
 func Prop(name, value string) {
+	name, value = interpolate(name), interpolate(value)
 	switch e := eval.Current().(type) {
 	case *expr.Person:
 		e.Properties[name] = value